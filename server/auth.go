@@ -0,0 +1,243 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// =============================================================================
+// AUTH & RATE LIMITING MIDDLEWARE
+// =============================================================================
+
+// scopeStagesWrite は POST/PUT/DELETE の書き込み系エンドポイントに必要なスコープ
+const scopeStagesWrite = "stages:write"
+
+// rateLimitBurst/rateLimitPerSecond は未認証リクエスト1 IPあたりのトークンバケット設定
+const (
+	rateLimitBurst     = 20
+	rateLimitPerSecond = 5
+)
+
+// bucketIdleTTL を超えて見ていないIPのバケットは次の掃除で捨てる。
+// バーストが満タンに戻るのに十分な時間が経っているので、捨てても挙動は変わらない
+const bucketIdleTTL = 10 * time.Minute
+
+// bucketSweepInterval はアイドルバケットの掃除間隔
+const bucketSweepInterval = time.Minute
+
+// publicReadsAllowed は読み取り専用のGETを未認証で許可するかどうか。
+// STAGES_PUBLIC_READS=false で閉じられる（デフォルトは公開）
+func publicReadsAllowed() bool {
+	v := os.Getenv("STAGES_PUBLIC_READS")
+	if v == "" {
+		return true
+	}
+	allowed, err := strconv.ParseBool(v)
+	if err != nil {
+		return true
+	}
+	return allowed
+}
+
+// tokenStore はBearerトークンからスコープ一覧を引く。STAGES_AUTH_TOKENS に
+// "token:scope1,scope2;token2:scope1" の形式で与える（環境変数 or 設定ファイルの代わり）
+type tokenStore struct {
+	scopesByToken map[string][]string
+}
+
+func loadTokenStore() *tokenStore {
+	store := &tokenStore{scopesByToken: make(map[string][]string)}
+
+	raw := os.Getenv("STAGES_AUTH_TOKENS")
+	if raw == "" {
+		return store
+	}
+
+	for _, entry := range strings.Split(raw, ";") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		parts := strings.SplitN(entry, ":", 2)
+		token := strings.TrimSpace(parts[0])
+		if token == "" {
+			continue
+		}
+		var scopes []string
+		if len(parts) == 2 {
+			for _, s := range strings.Split(parts[1], ",") {
+				if s = strings.TrimSpace(s); s != "" {
+					scopes = append(scopes, s)
+				}
+			}
+		}
+		store.scopesByToken[token] = scopes
+	}
+	return store
+}
+
+func (s *tokenStore) hasScope(token, scope string) bool {
+	scopes, ok := s.scopesByToken[token]
+	if !ok {
+		return false
+	}
+	for _, sc := range scopes {
+		if sc == scope {
+			return true
+		}
+	}
+	return false
+}
+
+var defaultTokenStore = loadTokenStore()
+
+// bucket はIPごとのトークンバケットの状態
+type bucket struct {
+	tokens   float64
+	lastSeen time.Time
+}
+
+// ipRateLimiter は未認証リクエストをIP単位でレート制限するトークンバケット
+type ipRateLimiter struct {
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+func newIPRateLimiter() *ipRateLimiter {
+	l := &ipRateLimiter{buckets: make(map[string]*bucket)}
+	go l.sweepIdleBuckets()
+	return l
+}
+
+// sweepIdleBuckets はバックグラウンドで定期的に bucketIdleTTL より古いバケットを捨てる。
+// これをしないと、未認証リクエストを送ってきたIPごとにメモリが増え続ける
+func (l *ipRateLimiter) sweepIdleBuckets() {
+	ticker := time.NewTicker(bucketSweepInterval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		l.evictIdle(now)
+	}
+}
+
+func (l *ipRateLimiter) evictIdle(now time.Time) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for ip, b := range l.buckets {
+		if now.Sub(b.lastSeen) > bucketIdleTTL {
+			delete(l.buckets, ip)
+		}
+	}
+}
+
+// allow はIPの残りトークン数を消費し、許可の可否と残数を返す
+func (l *ipRateLimiter) allow(ip string) (ok bool, remaining int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	b, exists := l.buckets[ip]
+	if !exists {
+		b = &bucket{tokens: rateLimitBurst, lastSeen: now}
+		l.buckets[ip] = b
+	}
+
+	elapsed := now.Sub(b.lastSeen).Seconds()
+	b.tokens += elapsed * rateLimitPerSecond
+	if b.tokens > rateLimitBurst {
+		b.tokens = rateLimitBurst
+	}
+	b.lastSeen = now
+
+	if b.tokens < 1 {
+		return false, 0
+	}
+	b.tokens--
+	return true, int(b.tokens)
+}
+
+var defaultRateLimiter = newIPRateLimiter()
+
+// requiredScope はメソッドから必要なスコープを決める。GETは公開設定に従う
+func requiredScope(method string) (scope string, required bool) {
+	if method == http.MethodGet {
+		if publicReadsAllowed() {
+			return "", false
+		}
+		return "stages:read", true
+	}
+	return scopeStagesWrite, true
+}
+
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// authMiddleware はBearerトークン認証と未認証リクエストのIPレート制限を行う。
+// router.go で corsMiddleware の内側に積まれるため、401/429のレスポンスにも
+// CORSヘッダは既に付与された状態で返る。OPTIONSは通常corsMiddlewareで完結するが、
+// 念のためここでも素通りさせる。
+func authMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		scope, required := requiredScope(r.Method)
+
+		token := bearerToken(r)
+		authenticated := token != "" && defaultTokenStore.hasScope(token, scope)
+
+		// 未認証のリクエストはIP単位でレート制限する。これは公開GETだけでなく、
+		// トークン欠落/不正で結局401になるリクエスト（＝トークン探り当ての本命）にも効かせる
+		if !authenticated {
+			ip := clientIP(r)
+			ok, remaining := defaultRateLimiter.allow(ip)
+			w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+			if !ok {
+				w.Header().Set("Retry-After", "1")
+				http.Error(w, "Too many requests", http.StatusTooManyRequests)
+				return
+			}
+		}
+
+		if required && !authenticated {
+			if token != "" {
+				// トークンはあるがスコープ不足、あるいは無効
+				challengeUnauthorized(w, "invalid_token")
+				return
+			}
+			challengeUnauthorized(w, "")
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func bearerToken(r *http.Request) string {
+	header := r.Header.Get("Authorization")
+	const prefix = "Bearer "
+	if !strings.HasPrefix(header, prefix) {
+		return ""
+	}
+	return strings.TrimSpace(strings.TrimPrefix(header, prefix))
+}
+
+func challengeUnauthorized(w http.ResponseWriter, errCode string) {
+	challenge := `Bearer realm="stages"`
+	if errCode != "" {
+		challenge += `, error="` + errCode + `"`
+	}
+	w.Header().Set("WWW-Authenticate", challenge)
+	http.Error(w, "Unauthorized", http.StatusUnauthorized)
+}
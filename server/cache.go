@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+)
+
+// =============================================================================
+// CONDITIONAL GET / CACHING
+// =============================================================================
+
+// stageCacheMaxAge はステージペイロードに付与するCache-Controlのmax-age（秒）。
+// ステージJSONはほぼ静的なので、ポーリングするクライアントやCDNでの再利用を想定して長めにとる
+const stageCacheMaxAge = 60
+
+// etagFor はペイロードのSHA-256からstrong ETagを生成する
+func etagFor(data []byte) string {
+	sum := sha256.Sum256(data)
+	return `"` + hex.EncodeToString(sum[:]) + `"`
+}
+
+// writeCachedJSON は payload をJSONとして書き出しつつ、ETag/Last-Modified/Cache-Control を付与し、
+// If-None-Match が一致する場合は 304 Not Modified を返す。
+// gzip圧縮自体は router.go の middleware.Compress がグローバルに面倒を見るので、ここでは扱わない。
+func writeCachedJSON(w http.ResponseWriter, r *http.Request, payload interface{}, modTime time.Time) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	etag := etagFor(data)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", stageCacheMaxAge))
+	if !modTime.IsZero() {
+		w.Header().Set("Last-Modified", modTime.UTC().Format(http.TimeFormat))
+	}
+
+	if match := r.Header.Get("If-None-Match"); match != "" && match == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return nil
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, err = w.Write(data)
+	return err
+}
+
+// statModTime はファイルの最終更新時刻を返す。取得できない場合はゼロ値を返す
+func statModTime(path string) time.Time {
+	info, err := os.Stat(path)
+	if err != nil {
+		return time.Time{}
+	}
+	return info.ModTime()
+}
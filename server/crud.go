@@ -0,0 +1,268 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// =============================================================================
+// STAGE AUTHORING (CREATE / UPDATE / DELETE)
+// =============================================================================
+
+// stagesMu はステージファイルと manifest.json への書き込みを直列化する。
+// 両者は常に一緒に更新されるため、読み込み側も含めて同じロックで守る。
+var stagesMu sync.RWMutex
+
+// StageWriteRequest は POST/PUT のリクエストボディ。StageConfig に加えて
+// manifest.json 専用のフィールド（required_level）を一緒に受け取る。
+type StageWriteRequest struct {
+	StageConfig
+	RequiredLevel int `json:"required_level"`
+}
+
+// writeFileAtomic は同一ディレクトリに一時ファイルを作ってから rename することで、
+// 書き込み途中のファイルを他の読み手に見せないようにする。
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, ".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpName := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpName)
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+
+	if err := os.Rename(tmpName, path); err != nil {
+		os.Remove(tmpName)
+		return err
+	}
+	return nil
+}
+
+// nextStageID は manifest の既存IDと衝突しない新しいステージIDを発行する
+func nextStageID(manifest *Manifest) string {
+	existing := make(map[string]bool, len(manifest.Stages))
+	for _, s := range manifest.Stages {
+		existing[s.ID] = true
+	}
+	for i := len(manifest.Stages) + 1; ; i++ {
+		candidate := fmt.Sprintf("stage-%d", i)
+		if !existing[candidate] {
+			return candidate
+		}
+	}
+}
+
+// listItemFromRequest は書き込みリクエストから manifest 用のサマリを組み立てる
+func listItemFromRequest(id string, req *StageWriteRequest) StageListItem {
+	return StageListItem{
+		ID:            id,
+		Title:         req.Meta.Title,
+		Description:   req.Meta.Description,
+		Budget:        req.Meta.Budget,
+		SLATarget:     req.Meta.SLATarget,
+		RequiredLevel: req.RequiredLevel,
+	}
+}
+
+// saveStageFile はステージ設定をJSONとしてatomicに書き出す
+func saveStageFile(stageID string, config *StageConfig) error {
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(filepath.Join(stagesDir, stageID+".json"), data)
+}
+
+// saveManifest はmanifest.jsonをatomicに書き出す
+func saveManifest(manifest *Manifest) error {
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(manifestFile, data)
+}
+
+func decodeStageWriteRequest(r *http.Request) (*StageWriteRequest, *ValidationResult, error) {
+	raw, err := io.ReadAll(r.Body)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var req StageWriteRequest
+	if err := json.Unmarshal(raw, &req); err != nil {
+		return nil, nil, err
+	}
+
+	result := validateStageConfig(raw, &req.StageConfig)
+	return &req, result, nil
+}
+
+// handleCreateStage は POST /api/stages - サーバー側でIDを採番してステージを新規作成する
+func handleCreateStage(w http.ResponseWriter, r *http.Request) {
+	req, result, err := decodeStageWriteRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !result.Valid() {
+		respondValidationFailure(w, result)
+		return
+	}
+
+	stagesMu.Lock()
+	defer stagesMu.Unlock()
+
+	manifest, err := loadManifest()
+	if err != nil {
+		log.Printf("Error loading manifest: %v", err)
+		http.Error(w, "Failed to load manifest", http.StatusInternalServerError)
+		return
+	}
+
+	stageID := nextStageID(manifest)
+
+	if err := saveStageFile(stageID, &req.StageConfig); err != nil {
+		log.Printf("Error writing stage %s: %v", stageID, err)
+		http.Error(w, "Failed to write stage", http.StatusInternalServerError)
+		return
+	}
+
+	item := listItemFromRequest(stageID, req)
+	manifest.Stages = append(manifest.Stages, item)
+	if err := saveManifest(manifest); err != nil {
+		log.Printf("Error writing manifest after creating %s: %v", stageID, err)
+		http.Error(w, "Failed to update manifest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Location", "/api/stages/"+stageID)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(item)
+}
+
+// handleReplaceStage は PUT /api/stages/{id} - 既存ステージをまるごと置き換える
+func handleReplaceStage(w http.ResponseWriter, r *http.Request) {
+	stageID := chi.URLParam(r, "id")
+	if stageID == "" {
+		http.Error(w, "Stage ID is required", http.StatusBadRequest)
+		return
+	}
+
+	req, result, err := decodeStageWriteRequest(r)
+	if err != nil {
+		http.Error(w, "Invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !result.Valid() {
+		respondValidationFailure(w, result)
+		return
+	}
+
+	stagesMu.Lock()
+	defer stagesMu.Unlock()
+
+	manifest, err := loadManifest()
+	if err != nil {
+		log.Printf("Error loading manifest: %v", err)
+		http.Error(w, "Failed to load manifest", http.StatusInternalServerError)
+		return
+	}
+
+	idx := -1
+	for i, s := range manifest.Stages {
+		if s.ID == stageID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		http.Error(w, "Stage not found", http.StatusNotFound)
+		return
+	}
+
+	if err := saveStageFile(stageID, &req.StageConfig); err != nil {
+		log.Printf("Error writing stage %s: %v", stageID, err)
+		http.Error(w, "Failed to write stage", http.StatusInternalServerError)
+		return
+	}
+
+	item := listItemFromRequest(stageID, req)
+	manifest.Stages[idx] = item
+	if err := saveManifest(manifest); err != nil {
+		log.Printf("Error writing manifest after updating %s: %v", stageID, err)
+		http.Error(w, "Failed to update manifest", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(item)
+}
+
+// handleDeleteStage は DELETE /api/stages/{id} - ステージを削除する
+func handleDeleteStage(w http.ResponseWriter, r *http.Request) {
+	stageID := chi.URLParam(r, "id")
+	if stageID == "" {
+		http.Error(w, "Stage ID is required", http.StatusBadRequest)
+		return
+	}
+
+	stagesMu.Lock()
+	defer stagesMu.Unlock()
+
+	manifest, err := loadManifest()
+	if err != nil {
+		log.Printf("Error loading manifest: %v", err)
+		http.Error(w, "Failed to load manifest", http.StatusInternalServerError)
+		return
+	}
+
+	idx := -1
+	for i, s := range manifest.Stages {
+		if s.ID == stageID {
+			idx = i
+			break
+		}
+	}
+	if idx == -1 {
+		http.Error(w, "Stage not found", http.StatusNotFound)
+		return
+	}
+
+	manifest.Stages = append(manifest.Stages[:idx], manifest.Stages[idx+1:]...)
+	if err := saveManifest(manifest); err != nil {
+		log.Printf("Error writing manifest after deleting %s: %v", stageID, err)
+		http.Error(w, "Failed to update manifest", http.StatusInternalServerError)
+		return
+	}
+
+	if err := os.Remove(filepath.Join(stagesDir, stageID+".json")); err != nil && !os.IsNotExist(err) {
+		log.Printf("Error removing stage file %s: %v", stageID, err)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// respondValidationFailure は 422 とスキーマ違反の一覧を書き込む
+func respondValidationFailure(w http.ResponseWriter, result *ValidationResult) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// chdirTemp switches the process into a fresh stages/+manifest.json tree for the
+// duration of the test and restores the original working directory afterwards.
+func chdirTemp(t *testing.T) string {
+	t.Helper()
+
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, stagesDir), 0o755); err != nil {
+		t.Fatalf("failed to create stages dir: %v", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, manifestFile), []byte(`{"stages":[]}`), 0o644); err != nil {
+		t.Fatalf("failed to seed manifest: %v", err)
+	}
+
+	original, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("failed to get working directory: %v", err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("failed to chdir: %v", err)
+	}
+	t.Cleanup(func() {
+		if err := os.Chdir(original); err != nil {
+			t.Fatalf("failed to restore working directory: %v", err)
+		}
+	})
+
+	return dir
+}
+
+func requestWithURLParam(method, target, body, id string) *http.Request {
+	req := httptest.NewRequest(method, target, strings.NewReader(body))
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add("id", id)
+	return req.WithContext(context.WithValue(req.Context(), chi.RouteCtxKey, rctx))
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "out.json")
+
+	if err := writeFileAtomic(path, []byte(`{"a":1}`)); err != nil {
+		t.Fatalf("writeFileAtomic failed: %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("failed to read written file: %v", err)
+	}
+	if string(data) != `{"a":1}` {
+		t.Errorf("unexpected content: %s", data)
+	}
+
+	// A second write should replace the file in place, leaving no temp files behind.
+	if err := writeFileAtomic(path, []byte(`{"a":2}`)); err != nil {
+		t.Fatalf("writeFileAtomic overwrite failed: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	if string(data) != `{"a":2}` {
+		t.Errorf("unexpected content after overwrite: %s", data)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatalf("failed to list dir: %v", err)
+	}
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".tmp-") {
+			t.Errorf("leftover temp file: %s", e.Name())
+		}
+	}
+}
+
+func TestHandleCreateStage_WritesFileAndManifest(t *testing.T) {
+	chdirTemp(t)
+
+	body := `{"meta":{"title":"New Stage","budget":50,"sla_target":0.5},
+		"map":{"fixed_nodes":[{"id":"gw1"}]},
+		"waves":[{"source_id":"gw1","count":1,"duration_ms":1000,"speed":1}],
+		"required_level":2}`
+
+	req := httptest.NewRequest(http.MethodPost, "/api/stages", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handleCreateStage(w, req)
+
+	if w.Code != http.StatusCreated {
+		t.Fatalf("expected 201, got %d: %s", w.Code, w.Body.String())
+	}
+
+	location := w.Header().Get("Location")
+	if !strings.HasPrefix(location, "/api/stages/") {
+		t.Errorf("expected Location header to point at the new stage, got %q", location)
+	}
+	stageID := strings.TrimPrefix(location, "/api/stages/")
+
+	if _, err := os.Stat(filepath.Join(stagesDir, stageID+".json")); err != nil {
+		t.Errorf("expected stage file to exist: %v", err)
+	}
+
+	manifest, err := loadManifest()
+	if err != nil {
+		t.Fatalf("failed to load manifest: %v", err)
+	}
+	if len(manifest.Stages) != 1 {
+		t.Fatalf("expected 1 manifest entry, got %d", len(manifest.Stages))
+	}
+	got := manifest.Stages[0]
+	if got.ID != stageID || got.Title != "New Stage" || got.RequiredLevel != 2 {
+		t.Errorf("manifest entry does not match request: %+v", got)
+	}
+}
+
+func TestHandleCreateStage_InvalidBodyReturns422(t *testing.T) {
+	chdirTemp(t)
+
+	body := `{"meta":{},"map":{"fixed_nodes":[]},"waves":[]}`
+	req := httptest.NewRequest(http.MethodPost, "/api/stages", strings.NewReader(body))
+	w := httptest.NewRecorder()
+
+	handleCreateStage(w, req)
+
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("expected 422, got %d: %s", w.Code, w.Body.String())
+	}
+
+	manifest, err := loadManifest()
+	if err != nil {
+		t.Fatalf("failed to load manifest: %v", err)
+	}
+	if len(manifest.Stages) != 0 {
+		t.Errorf("invalid stage should not have been written to the manifest")
+	}
+}
+
+func TestHandleDeleteStage_RemovesFileAndManifestEntry(t *testing.T) {
+	chdirTemp(t)
+
+	createBody := `{"meta":{"title":"Doomed","budget":10,"sla_target":0.1},
+		"map":{"fixed_nodes":[{"id":"gw1"}]},
+		"waves":[{"source_id":"gw1","count":1,"duration_ms":1000,"speed":1}]}`
+	createReq := httptest.NewRequest(http.MethodPost, "/api/stages", strings.NewReader(createBody))
+	createW := httptest.NewRecorder()
+	handleCreateStage(createW, createReq)
+	if createW.Code != http.StatusCreated {
+		t.Fatalf("setup: failed to create stage: %d %s", createW.Code, createW.Body.String())
+	}
+	var created StageListItem
+	if err := json.Unmarshal(createW.Body.Bytes(), &created); err != nil {
+		t.Fatalf("setup: failed to parse created stage: %v", err)
+	}
+
+	deleteReq := requestWithURLParam(http.MethodDelete, "/api/stages/"+created.ID, "", created.ID)
+	deleteW := httptest.NewRecorder()
+	handleDeleteStage(deleteW, deleteReq)
+
+	if deleteW.Code != http.StatusNoContent {
+		t.Fatalf("expected 204, got %d: %s", deleteW.Code, deleteW.Body.String())
+	}
+
+	if _, err := os.Stat(filepath.Join(stagesDir, created.ID+".json")); !os.IsNotExist(err) {
+		t.Errorf("expected stage file to be removed, stat err: %v", err)
+	}
+
+	manifest, err := loadManifest()
+	if err != nil {
+		t.Fatalf("failed to load manifest: %v", err)
+	}
+	if len(manifest.Stages) != 0 {
+		t.Errorf("expected manifest entry to be removed, got %+v", manifest.Stages)
+	}
+}
+
+func TestHandleDeleteStage_UnknownIDReturns404(t *testing.T) {
+	chdirTemp(t)
+
+	req := requestWithURLParam(http.MethodDelete, "/api/stages/nope", "", "nope")
+	w := httptest.NewRecorder()
+	handleDeleteStage(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("expected 404, got %d", w.Code)
+	}
+}
@@ -6,7 +6,8 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
-	"strings"
+
+	"github.com/go-chi/chi/v5"
 )
 
 // =============================================================================
@@ -70,19 +71,20 @@ type Manifest struct {
 // CORS MIDDLEWARE
 // =============================================================================
 
-func corsMiddleware(next http.HandlerFunc) http.HandlerFunc {
-	return func(w http.ResponseWriter, r *http.Request) {
+// corsMiddleware は chi の r.Use(...) に載せる標準形の net/http ミドルウェア
+func corsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.Header().Set("Access-Control-Allow-Origin", "*")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type")
+		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
 
 		if r.Method == "OPTIONS" {
 			w.WriteHeader(http.StatusOK)
 			return
 		}
 
-		next(w, r)
-	}
+		next.ServeHTTP(w, r)
+	})
 }
 
 // =============================================================================
@@ -151,8 +153,9 @@ func handleGetStages(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(stages)
+	if err := writeCachedJSON(w, r, stages, statModTime(manifestFile)); err != nil {
+		log.Printf("Error writing stage list response: %v", err)
+	}
 }
 
 // handleGetStage は GET /api/stages/{id} - 特定ステージの詳細を返す
@@ -162,16 +165,13 @@ func handleGetStage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	// パスから stage ID を抽出: /api/stages/{id}
-	path := strings.TrimPrefix(r.URL.Path, "/api/stages/")
-	stageID := strings.TrimSpace(path)
-
+	stageID := chi.URLParam(r, "id")
 	if stageID == "" {
 		http.Error(w, "Stage ID is required", http.StatusBadRequest)
 		return
 	}
 
-	config, err := loadStageConfig(stageID)
+	config, result, err := loadAndValidateStageConfig(stageID)
 	if err != nil {
 		if os.IsNotExist(err) {
 			http.Error(w, "Stage not found", http.StatusNotFound)
@@ -182,20 +182,18 @@ func handleGetStage(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(config)
-}
-
-// =============================================================================
-// ROUTER
-// =============================================================================
-
-func setupRoutes() {
-	// /api/stages - 一覧
-	http.HandleFunc("/api/stages", corsMiddleware(handleGetStages))
+	if !result.Valid() {
+		log.Printf("Stage %s failed schema validation: %d error(s)", stageID, len(result.Errors))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
 
-	// /api/stages/{id} - 詳細
-	http.HandleFunc("/api/stages/", corsMiddleware(handleGetStage))
+	stagePath := filepath.Join(stagesDir, stageID+".json")
+	if err := writeCachedJSON(w, r, config, statModTime(stagePath)); err != nil {
+		log.Printf("Error writing stage response for %s: %v", stageID, err)
+	}
 }
 
 // =============================================================================
@@ -208,15 +206,17 @@ func main() {
 		log.Printf("Warning: stages directory '%s' does not exist", stagesDir)
 	}
 
-	setupRoutes()
+	r := setupRoutes()
 
 	addr := ":8080"
 	log.Printf("REST API server starting on %s", addr)
 	log.Printf("Endpoints:")
 	log.Printf("  GET http://localhost%s/api/stages      - Stage list", addr)
 	log.Printf("  GET http://localhost%s/api/stages/{id} - Stage detail", addr)
+	log.Printf("  GET http://localhost%s/api/stages/{id}/validate - Stage schema validation", addr)
+	log.Printf("  GET http://localhost%s/api/stages/{id}/simulate - Stage wave playback (ws)", addr)
 
-	if err := http.ListenAndServe(addr, nil); err != nil {
+	if err := http.ListenAndServe(addr, r); err != nil {
 		log.Fatal("ListenAndServe: ", err)
 	}
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+)
+
+// =============================================================================
+// ROUTER
+// =============================================================================
+
+// setupRoutes はルーティングを組み立てる。将来の管理系エンドポイントは
+// 別の chi.Router グループとして生やせるよう、/api/stages をサブルータに切り出している。
+func setupRoutes() chi.Router {
+	r := chi.NewRouter()
+
+	r.Use(middleware.Logger)
+	r.Use(middleware.Recoverer)
+	r.Use(middleware.Compress(5))
+	r.Use(corsMiddleware)
+	r.Use(authMiddleware)
+
+	r.Route("/api/stages", func(r chi.Router) {
+		r.Get("/", handleGetStages)
+		r.Post("/", handleCreateStage)
+
+		r.Route("/{id}", func(r chi.Router) {
+			r.Get("/", handleGetStage)
+			r.Put("/", handleReplaceStage)
+			r.Delete("/", handleDeleteStage)
+			r.Get("/validate", handleValidateStage)
+			r.Get("/simulate", handleSimulateStage)
+		})
+	})
+
+	return r
+}
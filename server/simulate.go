@@ -0,0 +1,276 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"math/rand"
+	"net/http"
+	"os"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/gorilla/websocket"
+)
+
+// =============================================================================
+// STAGE SIMULATION
+// =============================================================================
+
+// simTickMs は配信する tick フレームの間隔（シミュレーション時間換算）
+const simTickMs = 100
+
+// simPacketLifetimeMs はパケットがスポーンしてから目的地に到達したとみなすまでの時間。
+// 経路長のモデルを持たないため固定値で代用し、超過したら despawn する
+const simPacketLifetimeMs = 5000
+
+var simUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// フロントエンドは別オリジンから接続してくるため、GETハンドラ同様に許可する
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// simSpawnEvent は1パケットの出現予定を表す（スケジュール構築時に使う内部表現）
+type simSpawnEvent struct {
+	TimeMs   int
+	PacketID string
+	SourceID string
+	Speed    float64
+}
+
+// simPacketState は稼働中パケットの現在位置（スポーンからの経過時間で代用）
+type simPacketState struct {
+	PacketID  string  `json:"packetId"`
+	SourceID  string  `json:"sourceId"`
+	Speed     float64 `json:"speed"`
+	ElapsedMs int     `json:"elapsedMs"`
+}
+
+// simFrame はクライアントへ送るフレーム。type に応じて使うフィールドが変わる
+type simFrame struct {
+	T        int              `json:"t"`
+	Type     string           `json:"type"`
+	PacketID string           `json:"packetId,omitempty"`
+	SourceID string           `json:"sourceId,omitempty"`
+	Speed    float64          `json:"speed,omitempty"`
+	Packets  []simPacketState `json:"packets,omitempty"`
+}
+
+// simControlMessage はクライアントから送られてくる制御メッセージ
+type simControlMessage struct {
+	Type            string  `json:"type"`
+	SeekMs          int     `json:"seekMs"`
+	SpeedMultiplier float64 `json:"speedMultiplier"`
+}
+
+// buildSpawnSchedule は Waves からスポーンイベント列を構築する。
+// 同じ seed を与えれば、同じ順序・同じタイミングのスケジュールが得られる
+// （seed は Wave 内での出現間隔のジッタにのみ使う）。
+func buildSpawnSchedule(config *StageConfig, seed int64) []simSpawnEvent {
+	rng := rand.New(rand.NewSource(seed))
+
+	var events []simSpawnEvent
+	for waveIdx, wave := range config.Waves {
+		if wave.Count <= 0 {
+			continue
+		}
+		// DurationMs の間に Count 個を等間隔＋ジッタで割り振る
+		interval := 0
+		if wave.Count > 1 && wave.DurationMs > 0 {
+			interval = wave.DurationMs / wave.Count
+		}
+		for i := 0; i < wave.Count; i++ {
+			jitter := 0
+			if interval > 0 {
+				jitter = rng.Intn(interval)
+			}
+			spawnAt := wave.TimeStartMs + i*interval + jitter
+			events = append(events, simSpawnEvent{
+				TimeMs:   spawnAt,
+				PacketID: formatPacketID(waveIdx, i),
+				SourceID: wave.SourceID,
+				Speed:    wave.Speed,
+			})
+		}
+	}
+
+	sort.Slice(events, func(i, j int) bool { return events[i].TimeMs < events[j].TimeMs })
+	return events
+}
+
+// formatPacketID はウェーブ番号と連番から決定的なパケットIDを組み立てる
+func formatPacketID(waveIdx, seq int) string {
+	return "w" + strconv.Itoa(waveIdx) + "-p" + strconv.Itoa(seq)
+}
+
+// simClock はシミュレーションの再生位置と再生速度を保持する（クライアント制御で変化する）
+type simClock struct {
+	mu       sync.Mutex
+	nowMs    int
+	speedMul float64
+	paused   bool
+}
+
+func newSimClock() *simClock {
+	return &simClock{speedMul: 1.0}
+}
+
+func (c *simClock) advance(deltaRealMs int) (nowMs int, paused bool, speedMul float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.paused {
+		c.nowMs += int(float64(deltaRealMs) * c.speedMul)
+	}
+	return c.nowMs, c.paused, c.speedMul
+}
+
+func (c *simClock) applyControl(msg simControlMessage) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	switch msg.Type {
+	case "pause":
+		c.paused = true
+	case "resume":
+		c.paused = false
+	case "seek":
+		c.nowMs = msg.SeekMs
+	case "setSpeedMultiplier":
+		if msg.SpeedMultiplier > 0 {
+			c.speedMul = msg.SpeedMultiplier
+		}
+	}
+}
+
+// handleSimulateStage は GET /api/stages/{id}/simulate - WebSocketでWave再生をストリームする
+func handleSimulateStage(w http.ResponseWriter, r *http.Request) {
+	stageID := chi.URLParam(r, "id")
+	if stageID == "" {
+		http.Error(w, "Stage ID is required", http.StatusBadRequest)
+		return
+	}
+
+	config, result, err := loadAndValidateStageConfig(stageID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "Stage not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error loading stage %s: %v", stageID, err)
+			http.Error(w, "Failed to load stage", http.StatusInternalServerError)
+		}
+		return
+	}
+	if !result.Valid() {
+		log.Printf("Refusing to simulate stage %s: failed schema validation with %d error(s)", stageID, len(result.Errors))
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(result)
+		return
+	}
+
+	seed := int64(0)
+	if raw := r.URL.Query().Get("seed"); raw != "" {
+		if parsed, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			seed = parsed
+		}
+	}
+
+	conn, err := simUpgrader.Upgrade(w, r, nil)
+	if err != nil {
+		log.Printf("Error upgrading to websocket for stage %s: %v", stageID, err)
+		return
+	}
+	defer conn.Close()
+
+	runStageSimulation(conn, config, seed)
+}
+
+// advanceSimulationTick は1tick分の状態遷移を計算する純粋関数。スポーン/despawn/tick/doneの
+// フレーム列と、更新後の spawnIdx を返す。websocketやクロックに依存しないのでテストしやすい。
+// active は呼び出し元が保持するマップを直接書き換える。
+func advanceSimulationTick(schedule []simSpawnEvent, spawnIdx int, active map[string]simSpawnEvent, nowMs int) (frames []simFrame, nextSpawnIdx int, done bool) {
+	for spawnIdx < len(schedule) && schedule[spawnIdx].TimeMs <= nowMs {
+		ev := schedule[spawnIdx]
+		active[ev.PacketID] = ev
+		frames = append(frames, simFrame{
+			T: nowMs, Type: "spawn",
+			PacketID: ev.PacketID, SourceID: ev.SourceID, Speed: ev.Speed,
+		})
+		spawnIdx++
+	}
+
+	for id, ev := range active {
+		if nowMs-ev.TimeMs < simPacketLifetimeMs {
+			continue
+		}
+		delete(active, id)
+		frames = append(frames, simFrame{T: nowMs, Type: "despawn", PacketID: id, SourceID: ev.SourceID})
+	}
+
+	packets := make([]simPacketState, 0, len(active))
+	for _, ev := range active {
+		packets = append(packets, simPacketState{
+			PacketID:  ev.PacketID,
+			SourceID:  ev.SourceID,
+			Speed:     ev.Speed,
+			ElapsedMs: nowMs - ev.TimeMs,
+		})
+	}
+	sort.Slice(packets, func(i, j int) bool { return packets[i].PacketID < packets[j].PacketID })
+	frames = append(frames, simFrame{T: nowMs, Type: "tick", Packets: packets})
+
+	if spawnIdx >= len(schedule) && len(active) == 0 {
+		frames = append(frames, simFrame{T: nowMs, Type: "done"})
+		done = true
+	}
+
+	return frames, spawnIdx, done
+}
+
+// runStageSimulation はスケジュールを組んで、擬似クロックに沿ってフレームを配信し続ける。
+// クライアントからの制御メッセージは別goroutineで読み、clockに即時反映する。
+func runStageSimulation(conn *websocket.Conn, config *StageConfig, seed int64) {
+	schedule := buildSpawnSchedule(config, seed)
+	clock := newSimClock()
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for {
+			var msg simControlMessage
+			if err := conn.ReadJSON(&msg); err != nil {
+				return
+			}
+			clock.applyControl(msg)
+		}
+	}()
+
+	active := make(map[string]simSpawnEvent)
+	spawnIdx := 0
+	ticker := time.NewTicker(simTickMs * time.Millisecond)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			nowMs, _, _ := clock.advance(simTickMs)
+
+			frames, nextSpawnIdx, finished := advanceSimulationTick(schedule, spawnIdx, active, nowMs)
+			spawnIdx = nextSpawnIdx
+
+			for _, frame := range frames {
+				if err := conn.WriteJSON(frame); err != nil {
+					return
+				}
+			}
+
+			if finished {
+				return
+			}
+		}
+	}
+}
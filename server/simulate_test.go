@@ -0,0 +1,155 @@
+package main
+
+import (
+	"testing"
+)
+
+func stageConfigWithSingleWave(count, durationMs int, speed float64) *StageConfig {
+	return &StageConfig{
+		Meta: Meta{Title: "t", Budget: 1, SLATarget: 0.5},
+		Map:  MapConfig{FixedNodes: []FixedNode{{ID: "gw1"}}},
+		Waves: []Wave{
+			{SourceID: "gw1", Count: count, DurationMs: durationMs, Speed: speed},
+		},
+	}
+}
+
+func TestBuildSpawnSchedule_DeterministicForSameSeed(t *testing.T) {
+	config := stageConfigWithSingleWave(20, 2000, 1.5)
+
+	first := buildSpawnSchedule(config, 42)
+	second := buildSpawnSchedule(config, 42)
+
+	if len(first) != len(second) {
+		t.Fatalf("expected same length, got %d and %d", len(first), len(second))
+	}
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("event %d differs between runs: %+v vs %+v", i, first[i], second[i])
+		}
+	}
+}
+
+func TestBuildSpawnSchedule_SortedByTime(t *testing.T) {
+	config := stageConfigWithSingleWave(20, 2000, 1.5)
+	schedule := buildSpawnSchedule(config, 7)
+
+	if len(schedule) != 20 {
+		t.Fatalf("expected 20 spawn events, got %d", len(schedule))
+	}
+	for i := 1; i < len(schedule); i++ {
+		if schedule[i].TimeMs < schedule[i-1].TimeMs {
+			t.Fatalf("schedule is not sorted: event %d (%d) before event %d (%d)",
+				i-1, schedule[i-1].TimeMs, i, schedule[i].TimeMs)
+		}
+	}
+}
+
+func TestBuildSpawnSchedule_DifferentSeedsCanDiffer(t *testing.T) {
+	config := stageConfigWithSingleWave(20, 2000, 1.5)
+
+	a := buildSpawnSchedule(config, 1)
+	b := buildSpawnSchedule(config, 2)
+
+	same := len(a) == len(b)
+	if same {
+		for i := range a {
+			if a[i] != b[i] {
+				same = false
+				break
+			}
+		}
+	}
+	if same {
+		t.Fatal("expected different seeds to produce different jitter, got identical schedules")
+	}
+}
+
+func TestFormatPacketID(t *testing.T) {
+	if got := formatPacketID(2, 5); got != "w2-p5" {
+		t.Errorf("unexpected packet ID: %s", got)
+	}
+}
+
+func TestAdvanceSimulationTick_SpawnsDueEvents(t *testing.T) {
+	schedule := []simSpawnEvent{
+		{TimeMs: 0, PacketID: "w0-p0", SourceID: "gw1", Speed: 1},
+		{TimeMs: 500, PacketID: "w0-p1", SourceID: "gw1", Speed: 1},
+	}
+	active := make(map[string]simSpawnEvent)
+
+	frames, spawnIdx, done := advanceSimulationTick(schedule, 0, active, 0)
+
+	if spawnIdx != 1 {
+		t.Fatalf("expected spawnIdx to advance to 1, got %d", spawnIdx)
+	}
+	if done {
+		t.Fatal("did not expect done yet")
+	}
+	if _, ok := active["w0-p0"]; !ok {
+		t.Fatal("expected w0-p0 to be active after spawning")
+	}
+	if !containsFrameType(frames, "spawn") {
+		t.Errorf("expected a spawn frame, got: %+v", frames)
+	}
+	if !containsFrameType(frames, "tick") {
+		t.Errorf("expected a tick frame, got: %+v", frames)
+	}
+}
+
+func TestAdvanceSimulationTick_DespawnsAfterLifetime(t *testing.T) {
+	schedule := []simSpawnEvent{
+		{TimeMs: 0, PacketID: "w0-p0", SourceID: "gw1", Speed: 1},
+	}
+	active := map[string]simSpawnEvent{
+		"w0-p0": schedule[0],
+	}
+
+	frames, _, _ := advanceSimulationTick(schedule, 1, active, simPacketLifetimeMs-1)
+	if _, stillActive := active["w0-p0"]; !stillActive {
+		t.Fatal("packet should still be active just before its lifetime expires")
+	}
+	if containsFrameType(frames, "despawn") {
+		t.Errorf("did not expect a despawn frame yet, got: %+v", frames)
+	}
+
+	frames, _, _ = advanceSimulationTick(schedule, 1, active, simPacketLifetimeMs)
+	if _, stillActive := active["w0-p0"]; stillActive {
+		t.Fatal("expected packet to be despawned once its lifetime elapses")
+	}
+	if !containsFrameType(frames, "despawn") {
+		t.Errorf("expected a despawn frame, got: %+v", frames)
+	}
+}
+
+func TestAdvanceSimulationTick_EmitsDoneOnceScheduleAndActiveAreEmpty(t *testing.T) {
+	schedule := []simSpawnEvent{
+		{TimeMs: 0, PacketID: "w0-p0", SourceID: "gw1", Speed: 1},
+	}
+	active := make(map[string]simSpawnEvent)
+
+	// First tick: spawn the only packet. Schedule exhausted but packet still active.
+	_, spawnIdx, done := advanceSimulationTick(schedule, 0, active, 0)
+	if done {
+		t.Fatal("did not expect done while a packet is still active")
+	}
+
+	// Second tick: once past the packet's lifetime it despawns, and with nothing
+	// left scheduled or active, a terminal frame should be emitted.
+	frames, _, done := advanceSimulationTick(schedule, spawnIdx, active, simPacketLifetimeMs)
+	if !done {
+		t.Fatal("expected done once the schedule is exhausted and no packets remain")
+	}
+	if !containsFrameType(frames, "done") {
+		t.Errorf("expected a done frame, got: %+v", frames)
+	}
+}
+
+func containsFrameType(frames []simFrame, frameType string) bool {
+	for _, f := range frames {
+		if f.Type == frameType {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/santhosh-tekuri/jsonschema/v5"
+)
+
+// =============================================================================
+// STAGE VALIDATION
+// =============================================================================
+
+// stageSchemaFile は stages/*.json が満たすべきJSON Schema（draft-07）
+const stageSchemaFile = "schema/stage.schema.json"
+
+// stageSchema はサーバー起動時に一度だけコンパイルされるステージ用スキーマ
+var stageSchema = compileStageSchema()
+
+func compileStageSchema() *jsonschema.Schema {
+	data, err := os.ReadFile(stageSchemaFile)
+	if err != nil {
+		log.Fatalf("Error reading stage schema %s: %v", stageSchemaFile, err)
+	}
+
+	compiler := jsonschema.NewCompiler()
+	if err := compiler.AddResource(stageSchemaFile, bytes.NewReader(data)); err != nil {
+		log.Fatalf("Error loading stage schema %s: %v", stageSchemaFile, err)
+	}
+
+	schema, err := compiler.Compile(stageSchemaFile)
+	if err != nil {
+		log.Fatalf("Error compiling stage schema %s: %v", stageSchemaFile, err)
+	}
+	return schema
+}
+
+// ValidationViolation は1件のスキーマ違反を表す
+type ValidationViolation struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// ValidationResult はステージ検証の結果（エラーと警告を分けて持つ）
+type ValidationResult struct {
+	Errors   []ValidationViolation `json:"errors"`
+	Warnings []ValidationViolation `json:"warnings"`
+}
+
+// Valid はエラーが1件もないかどうかを返す
+func (r *ValidationResult) Valid() bool {
+	return len(r.Errors) == 0
+}
+
+func (r *ValidationResult) addError(field, format string, args ...interface{}) {
+	r.Errors = append(r.Errors, ValidationViolation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+func (r *ValidationResult) addWarning(field, format string, args ...interface{}) {
+	r.Warnings = append(r.Warnings, ValidationViolation{Field: field, Message: fmt.Sprintf(format, args...)})
+}
+
+// validateAgainstSchema は生のJSONを stage.schema.json に照らして検証する。
+// 必須項目の欠落や範囲外の値（Budget/Speed/Countなど）はここで検出される。
+func validateAgainstSchema(raw []byte) []ValidationViolation {
+	var instance interface{}
+	if err := json.Unmarshal(raw, &instance); err != nil {
+		return []ValidationViolation{{Field: "", Message: "invalid JSON: " + err.Error()}}
+	}
+
+	err := stageSchema.Validate(instance)
+	if err == nil {
+		return nil
+	}
+
+	verr, ok := err.(*jsonschema.ValidationError)
+	if !ok {
+		return []ValidationViolation{{Field: "", Message: err.Error()}}
+	}
+	return flattenSchemaError(verr)
+}
+
+// flattenSchemaError は jsonschema.ValidationError の入れ子になった Causes を
+// 葉ノードまで辿って ValidationViolation の平坦なリストに変換する
+func flattenSchemaError(verr *jsonschema.ValidationError) []ValidationViolation {
+	if len(verr.Causes) == 0 {
+		return []ValidationViolation{{Field: verr.InstanceLocation, Message: verr.Message}}
+	}
+	var out []ValidationViolation
+	for _, cause := range verr.Causes {
+		out = append(out, flattenSchemaError(cause)...)
+	}
+	return out
+}
+
+// validateStageConfig はステージ設定を検証する。構造的なチェック（必須項目、範囲）は
+// stage.schema.json に任せ、ここでは複数フィールドにまたがる関係のチェック
+// （Waves の SourceID が FixedNodes に実在するか、DurationMs がステージ尺を超えていないか）だけを行う。
+func validateStageConfig(raw []byte, config *StageConfig) *ValidationResult {
+	result := &ValidationResult{}
+
+	result.Errors = append(result.Errors, validateAgainstSchema(raw)...)
+
+	knownNodes := make(map[string]bool, len(config.Map.FixedNodes))
+	for _, node := range config.Map.FixedNodes {
+		if node.ID != "" {
+			knownNodes[node.ID] = true
+		}
+	}
+
+	if len(config.Waves) == 0 {
+		result.addWarning("waves", "stage has no waves defined")
+	}
+
+	for i, wave := range config.Waves {
+		field := fmt.Sprintf("/waves/%d", i)
+
+		if wave.SourceID != "" && !knownNodes[wave.SourceID] {
+			result.addError(field+"/source_id", "source_id %q does not match any map.fixed_nodes entry", wave.SourceID)
+		}
+		if wave.TimeStartMs+wave.DurationMs > maxStageDurationMs {
+			result.addWarning(field, "wave ends at %dms, past the stage duration cap of %dms", wave.TimeStartMs+wave.DurationMs, maxStageDurationMs)
+		}
+	}
+
+	return result
+}
+
+// maxStageDurationMs はウェーブが想定上収まるべきステージ尺（スキーマ上のソフト上限）
+const maxStageDurationMs = 10 * 60 * 1000
+
+// loadAndValidateStageConfig はステージ設定を読み込み、検証結果を添えて返す。
+// 呼び出し側はエラーがあっても config 自体は受け取れるため、
+// レスポンスの組み立て方（422 か警告付き200か）を選べる。
+func loadAndValidateStageConfig(stageID string) (*StageConfig, *ValidationResult, error) {
+	raw, err := os.ReadFile(filepath.Join(stagesDir, stageID+".json"))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var config StageConfig
+	if err := json.Unmarshal(raw, &config); err != nil {
+		return nil, nil, err
+	}
+
+	result := validateStageConfig(raw, &config)
+	return &config, result, nil
+}
+
+// handleValidateStage は GET /api/stages/{id}/validate - スキーマ検証結果を返す
+func handleValidateStage(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+
+	stageID := chi.URLParam(r, "id")
+	if stageID == "" {
+		http.Error(w, "Stage ID is required", http.StatusBadRequest)
+		return
+	}
+
+	_, result, err := loadAndValidateStageConfig(stageID)
+	if err != nil {
+		if os.IsNotExist(err) {
+			http.Error(w, "Stage not found", http.StatusNotFound)
+		} else {
+			log.Printf("Error validating stage %s: %v", stageID, err)
+			http.Error(w, "Failed to load stage", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if !result.Valid() {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	json.NewEncoder(w).Encode(result)
+}
@@ -0,0 +1,156 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func validConfigJSON() string {
+	return `{
+		"meta": {"title": "Test Stage", "description": "d", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1", "type": "gateway", "x": 0, "y": 0}]},
+		"waves": [
+			{"time_start_ms": 0, "source_id": "gw1", "count": 5, "duration_ms": 1000, "packet_type": "normal", "speed": 1.5}
+		]
+	}`
+}
+
+func decodeAndValidate(t *testing.T, raw string) (*StageConfig, *ValidationResult, error) {
+	t.Helper()
+	var config StageConfig
+	if err := json.Unmarshal([]byte(raw), &config); err != nil {
+		return nil, nil, err
+	}
+	return &config, validateStageConfig([]byte(raw), &config), nil
+}
+
+func TestValidateStageConfig_Valid(t *testing.T) {
+	_, result, err := decodeAndValidate(t, validConfigJSON())
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !result.Valid() {
+		t.Fatalf("expected valid config, got errors: %+v", result.Errors)
+	}
+}
+
+func TestValidateStageConfig_MissingTitle(t *testing.T) {
+	raw := `{
+		"meta": {"budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": [{"source_id": "gw1", "count": 1, "duration_ms": 1000, "speed": 1}]
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if result.Valid() {
+		t.Fatalf("expected missing title to fail validation")
+	}
+}
+
+func TestValidateStageConfig_NegativeCountAndZeroSpeed(t *testing.T) {
+	raw := `{
+		"meta": {"title": "t", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": [{"source_id": "gw1", "count": -1, "duration_ms": 1000, "speed": 0}]
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if result.Valid() {
+		t.Fatal("expected negative count and zero speed to fail validation")
+	}
+	joined := joinMessages(result.Errors)
+	if !strings.Contains(joined, "count") {
+		t.Errorf("expected a count violation, got: %s", joined)
+	}
+	if !strings.Contains(joined, "speed") {
+		t.Errorf("expected a speed violation, got: %s", joined)
+	}
+}
+
+func TestValidateStageConfig_CountAboveSchemaMaximumFails(t *testing.T) {
+	raw := `{
+		"meta": {"title": "t", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": [{"source_id": "gw1", "count": 2000000000, "duration_ms": 1000, "speed": 1}]
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if result.Valid() {
+		t.Fatal("expected an unreasonably large count to fail schema validation")
+	}
+	if !strings.Contains(joinMessages(result.Errors), "count") {
+		t.Errorf("expected a count violation, got: %+v", result.Errors)
+	}
+}
+
+func TestValidateStageConfig_UnknownSourceID(t *testing.T) {
+	raw := `{
+		"meta": {"title": "t", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": [{"source_id": "does-not-exist", "count": 1, "duration_ms": 1000, "speed": 1}]
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if result.Valid() {
+		t.Fatal("expected unknown source_id to fail validation")
+	}
+	if !strings.Contains(joinMessages(result.Errors), "does-not-exist") {
+		t.Errorf("expected violation to mention the offending source_id, got: %+v", result.Errors)
+	}
+}
+
+func TestValidateStageConfig_WaveOverflowIsWarningNotError(t *testing.T) {
+	raw := `{
+		"meta": {"title": "t", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": [{"time_start_ms": 590000, "source_id": "gw1", "count": 1, "duration_ms": 60000, "speed": 1}]
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !result.Valid() {
+		t.Fatalf("wave overflow should only warn, got errors: %+v", result.Errors)
+	}
+	if len(result.Warnings) == 0 {
+		t.Fatal("expected a warning about the wave overflowing the stage duration cap")
+	}
+}
+
+func TestValidateStageConfig_NoWavesWarns(t *testing.T) {
+	raw := `{
+		"meta": {"title": "t", "budget": 100, "sla_target": 0.9},
+		"map": {"fixed_nodes": [{"id": "gw1"}]},
+		"waves": []
+	}`
+	_, result, err := decodeAndValidate(t, raw)
+	if err != nil {
+		t.Fatalf("unexpected decode error: %v", err)
+	}
+	if !result.Valid() {
+		t.Fatalf("empty waves should only warn, got errors: %+v", result.Errors)
+	}
+	if len(result.Warnings) == 0 {
+		t.Fatal("expected a warning about the stage having no waves")
+	}
+}
+
+func joinMessages(violations []ValidationViolation) string {
+	var sb strings.Builder
+	for _, v := range violations {
+		sb.WriteString(v.Field)
+		sb.WriteString(": ")
+		sb.WriteString(v.Message)
+		sb.WriteString("; ")
+	}
+	return sb.String()
+}